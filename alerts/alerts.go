@@ -0,0 +1,143 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alerts compares lake readings against configured thresholds
+// and fires notifications through one or more dispatchers when a
+// threshold is crossed.
+package alerts
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reading is the subset of a lake reading that alerts needs. Callers
+// adapt their own Lake type into a Reading.
+type Reading struct {
+	Name      string
+	MaxLevel  float64
+	Today     float64
+	Yesterday float64
+}
+
+// Kind identifies how a Threshold compares against a Reading.
+type Kind string
+
+const (
+	// Absolute compares Today directly against Value (in msm).
+	Absolute Kind = "absolute"
+	// Percentage compares Today, as a percentage of MaxLevel, against Value.
+	Percentage Kind = "percentage"
+	// Delta compares (Today - Yesterday) against Value.
+	Delta Kind = "delta"
+)
+
+// Threshold is one rule to check against a Reading.
+type Threshold struct {
+	Lake     string   `yaml:"lake"`
+	Kind     Kind     `yaml:"kind"`
+	Value    float64  `yaml:"value"`
+	CoolDown Duration `yaml:"cool_down"`
+}
+
+// Duration wraps time.Duration so it can be written as "6h" in YAML
+// instead of a raw nanosecond count.
+type Duration time.Duration
+
+// UnmarshalYAML lets a Duration be written as a Go duration string, e.g.
+// "6h" or "30m".
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// crossed reports whether r crosses t.
+func (t Threshold) crossed(r Reading) (bool, error) {
+	switch t.Kind {
+	case Absolute:
+		return r.Today >= t.Value, nil
+	case Percentage:
+		if r.MaxLevel == 0 {
+			return false, nil
+		}
+		return (r.Today/r.MaxLevel)*100 >= t.Value, nil
+	case Delta:
+		return (r.Today - r.Yesterday) >= t.Value, nil
+	default:
+		return false, fmt.Errorf("unknown threshold kind %q", t.Kind)
+	}
+}
+
+// StateStore persists the time a threshold last fired, so the same
+// crossing doesn't re-notify on every run within its cool-down window.
+// State is keyed by both lake and threshold kind, since a lake can have
+// more than one threshold configured (e.g. an absolute and a percentage
+// one) and each needs its own cool-down clock.
+type StateStore interface {
+	LastAlerted(lake string, kind Kind) (time.Time, error)
+	SetLastAlerted(lake string, kind Kind, t time.Time) error
+}
+
+// Config is the top level alerts configuration, as loaded from a config
+// file alongside the sources configuration.
+type Config struct {
+	Thresholds  []Threshold        `yaml:"thresholds"`
+	Dispatchers []DispatcherConfig `yaml:"dispatchers"`
+}
+
+// Check evaluates every threshold in cfg against readings, firing
+// dispatchers for any crossing whose cool-down window has elapsed, and
+// updates state accordingly.
+func Check(cfg Config, readings map[string]Reading, state StateStore, dispatchers []Dispatcher, now time.Time) error {
+	for _, t := range cfg.Thresholds {
+		r, ok := readings[t.Lake]
+		if !ok {
+			continue
+		}
+		crossed, err := t.crossed(r)
+		if err != nil {
+			return err
+		}
+		if !crossed {
+			continue
+		}
+
+		last, err := state.LastAlerted(t.Lake, t.Kind)
+		if err != nil {
+			return err
+		}
+		if t.CoolDown > 0 && now.Sub(last) < time.Duration(t.CoolDown) {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s crossed %s threshold %v (today: %v)", t.Lake, t.Kind, t.Value, r.Today)
+		for _, d := range dispatchers {
+			if err := d.Send(msg); err != nil {
+				return err
+			}
+		}
+		if err := state.SetLastAlerted(t.Lake, t.Kind, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
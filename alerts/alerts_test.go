@@ -0,0 +1,194 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdCrossed(t *testing.T) {
+	cases := []struct {
+		name string
+		t    Threshold
+		r    Reading
+		want bool
+	}{
+		{
+			name: "absolute above",
+			t:    Threshold{Kind: Absolute, Value: 400},
+			r:    Reading{Today: 401},
+			want: true,
+		},
+		{
+			name: "absolute below",
+			t:    Threshold{Kind: Absolute, Value: 400},
+			r:    Reading{Today: 399},
+			want: false,
+		},
+		{
+			name: "percentage above",
+			t:    Threshold{Kind: Percentage, Value: 50},
+			r:    Reading{MaxLevel: 800, Today: 500},
+			want: true,
+		},
+		{
+			name: "percentage with zero MaxLevel never crosses",
+			t:    Threshold{Kind: Percentage, Value: 0},
+			r:    Reading{MaxLevel: 0, Today: 0},
+			want: false,
+		},
+		{
+			name: "delta above",
+			t:    Threshold{Kind: Delta, Value: 1},
+			r:    Reading{Today: 10, Yesterday: 8},
+			want: true,
+		},
+		{
+			name: "delta below",
+			t:    Threshold{Kind: Delta, Value: 1},
+			r:    Reading{Today: 10, Yesterday: 9.5},
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.t.crossed(c.r)
+			if err != nil {
+				t.Fatalf("crossed() error = %v", err)
+			}
+			if got != c.want {
+				t.Errorf("crossed() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestThresholdCrossedUnknownKind(t *testing.T) {
+	_, err := Threshold{Kind: "bogus"}.crossed(Reading{})
+	if err == nil {
+		t.Error("crossed() error = nil, want error for unknown kind")
+	}
+}
+
+// fakeStateStore is an in-memory StateStore for testing Check without a
+// Firebase client.
+type fakeStateStore struct {
+	lastAlerted map[string]time.Time
+}
+
+func newFakeStateStore() *fakeStateStore {
+	return &fakeStateStore{lastAlerted: make(map[string]time.Time)}
+}
+
+func (s *fakeStateStore) key(lake string, kind Kind) string {
+	return lake + "/" + string(kind)
+}
+
+func (s *fakeStateStore) LastAlerted(lake string, kind Kind) (time.Time, error) {
+	return s.lastAlerted[s.key(lake, kind)], nil
+}
+
+func (s *fakeStateStore) SetLastAlerted(lake string, kind Kind, t time.Time) error {
+	s.lastAlerted[s.key(lake, kind)] = t
+	return nil
+}
+
+// countingDispatcher records how many times it was asked to send a
+// message, without actually delivering anything.
+type countingDispatcher struct {
+	sent int
+}
+
+func (d *countingDispatcher) Send(message string) error {
+	d.sent++
+	return nil
+}
+
+func TestCheckRespectsCoolDown(t *testing.T) {
+	cfg := Config{
+		Thresholds: []Threshold{
+			{Lake: "Lac Noir", Kind: Absolute, Value: 100, CoolDown: Duration(time.Hour)},
+		},
+	}
+	readings := map[string]Reading{"Lac Noir": {Name: "Lac Noir", Today: 101}}
+	state := newFakeStateStore()
+	dispatcher := &countingDispatcher{}
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := Check(cfg, readings, state, []Dispatcher{dispatcher}, now); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if dispatcher.sent != 1 {
+		t.Fatalf("sent = %d, want 1 after first crossing", dispatcher.sent)
+	}
+
+	// Same crossing 10 minutes later: still within the cool-down, must
+	// not re-fire.
+	if err := Check(cfg, readings, state, []Dispatcher{dispatcher}, now.Add(10*time.Minute)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if dispatcher.sent != 1 {
+		t.Errorf("sent = %d, want 1 (still within cool-down)", dispatcher.sent)
+	}
+
+	// Past the cool-down window: should fire again.
+	if err := Check(cfg, readings, state, []Dispatcher{dispatcher}, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if dispatcher.sent != 2 {
+		t.Errorf("sent = %d, want 2 (past cool-down)", dispatcher.sent)
+	}
+}
+
+// TestCheckCoolDownIsPerKind is a regression test: two thresholds on the
+// same lake with different kinds must not share a cool-down clock, so
+// firing one doesn't silently suppress the other.
+func TestCheckCoolDownIsPerKind(t *testing.T) {
+	cfg := Config{
+		Thresholds: []Threshold{
+			{Lake: "Lac Noir", Kind: Absolute, Value: 100, CoolDown: Duration(time.Hour)},
+			{Lake: "Lac Noir", Kind: Percentage, Value: 50, CoolDown: Duration(time.Hour)},
+		},
+	}
+	readings := map[string]Reading{
+		"Lac Noir": {Name: "Lac Noir", MaxLevel: 800, Today: 500},
+	}
+	state := newFakeStateStore()
+	dispatcher := &countingDispatcher{}
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := Check(cfg, readings, state, []Dispatcher{dispatcher}, now); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if dispatcher.sent != 2 {
+		t.Fatalf("sent = %d, want 2 (both thresholds cross on first run)", dispatcher.sent)
+	}
+
+	// Five minutes later, only the absolute threshold fires again (its
+	// cool-down was reset manually below); the percentage one must stay
+	// cooled down independently.
+	if err := state.SetLastAlerted("Lac Noir", Absolute, now.Add(-2*time.Hour)); err != nil {
+		t.Fatalf("SetLastAlerted() error = %v", err)
+	}
+	dispatcher.sent = 0
+	if err := Check(cfg, readings, state, []Dispatcher{dispatcher}, now.Add(5*time.Minute)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if dispatcher.sent != 1 {
+		t.Errorf("sent = %d, want 1 (only the absolute threshold's cool-down had elapsed)", dispatcher.sent)
+	}
+}
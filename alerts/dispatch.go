@@ -0,0 +1,129 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+)
+
+// Dispatcher delivers an alert message to one notification channel.
+type Dispatcher interface {
+	Send(message string) error
+}
+
+// DispatcherConfig describes one dispatcher entry in the alerts config
+// file. Exactly the fields relevant to Type are used.
+type DispatcherConfig struct {
+	Type string `yaml:"type"` // "webhook", "smtp" or "rest"
+
+	// webhook
+	URL string `yaml:"url"`
+
+	// smtp
+	SMTPAddr string   `yaml:"smtp_addr"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+
+	// rest (Mastodon/Matrix, ...)
+	Endpoint string            `yaml:"endpoint"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// BuildDispatchers turns a list of DispatcherConfig into Dispatchers.
+func BuildDispatchers(configs []DispatcherConfig) ([]Dispatcher, error) {
+	dispatchers := make([]Dispatcher, 0, len(configs))
+	for _, c := range configs {
+		switch c.Type {
+		case "webhook":
+			dispatchers = append(dispatchers, &WebhookDispatcher{URL: c.URL})
+		case "smtp":
+			dispatchers = append(dispatchers, &SMTPDispatcher{Addr: c.SMTPAddr, From: c.From, To: c.To})
+		case "rest":
+			dispatchers = append(dispatchers, &RESTDispatcher{Endpoint: c.Endpoint, Headers: c.Headers})
+		default:
+			return nil, fmt.Errorf("unknown dispatcher type %q", c.Type)
+		}
+	}
+	return dispatchers, nil
+}
+
+// WebhookDispatcher POSTs the alert message as JSON to a generic HTTP
+// webhook.
+type WebhookDispatcher struct {
+	URL string
+}
+
+func (d *WebhookDispatcher) Send(message string) error {
+	body, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(d.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %s", d.URL, resp.Status)
+	}
+	return nil
+}
+
+// SMTPDispatcher sends the alert message by e-mail.
+type SMTPDispatcher struct {
+	Addr string
+	From string
+	To   []string
+}
+
+func (d *SMTPDispatcher) Send(message string) error {
+	body := fmt.Sprintf("Subject: Lake level alert\r\n\r\n%s\r\n", message)
+	return smtp.SendMail(d.Addr, nil, d.From, d.To, []byte(body))
+}
+
+// RESTDispatcher posts the alert message to a simple REST endpoint, such
+// as a Mastodon or Matrix message API.
+type RESTDispatcher struct {
+	Endpoint string
+	Headers  map[string]string
+}
+
+func (d *RESTDispatcher) Send(message string) error {
+	body, err := json.Marshal(map[string]string{"status": message, "body": message})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, d.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range d.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("rest dispatcher %s returned status %s", d.Endpoint, resp.Status)
+	}
+	return nil
+}
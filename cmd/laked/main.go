@@ -0,0 +1,88 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command laked runs the lake-level scrape/Firebase-write pass outside
+// of App Engine, either once (for cron-style invocation) or on a
+// recurring ticker as a long-lived daemon.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+
+	"github.com/supcik/lake-level-updater-2018/internal/lakes"
+)
+
+const defaultInterval = 15 * time.Minute
+
+func main() {
+	interval := flag.Duration("interval", defaultInterval, "time between scrape passes")
+	once := flag.Bool("once", false, "run a single scrape pass and exit, instead of looping")
+	metricsAddr := flag.String("metrics-addr", ":9090", "address to serve /metrics on (not served with --once), empty to disable")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dbClient, err := lakes.Database(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("error connecting to database")
+	}
+	client := http.DefaultClient
+
+	if *once {
+		if err := lakes.Run(ctx, client, dbClient); err != nil {
+			log.Fatal().Err(err).Msg("error running scrape pass")
+		}
+		return
+	}
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Error().Err(err).Msg("metrics server stopped")
+			}
+		}()
+		log.Info().Str("addr", *metricsAddr).Msg("serving /metrics")
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	log.Info().Dur("interval", *interval).Msg("laked started")
+	for {
+		if err := lakes.Run(ctx, client, dbClient); err != nil {
+			log.Error().Err(err).Msg("error running scrape pass")
+		}
+		select {
+		case <-ticker.C:
+		case s := <-sig:
+			log.Info().Str("signal", s.String()).Msg("shutting down")
+			return
+		}
+	}
+}
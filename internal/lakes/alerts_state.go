@@ -0,0 +1,51 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"context"
+	"time"
+
+	"firebase.google.com/go/db"
+
+	"github.com/supcik/lake-level-updater-2018/alerts"
+)
+
+// firebaseAlertState persists alerts.Check's "last-alerted" state under
+// /alerts/<lake>/<kind> so the same crossing doesn't re-fire on every
+// cron tick. Keying on kind as well as lake keeps, say, an absolute and
+// a percentage threshold on the same lake on independent cool-down
+// clocks.
+type firebaseAlertState struct {
+	ctx context.Context
+	db  *db.Client
+}
+
+func (s *firebaseAlertState) LastAlerted(lake string, kind alerts.Kind) (time.Time, error) {
+	var stamp string
+	if err := s.db.NewRef("/alerts/" + lake + "/" + string(kind)).Get(s.ctx, &stamp); err != nil {
+		return time.Time{}, err
+	}
+	if stamp == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, stamp)
+}
+
+func (s *firebaseAlertState) SetLastAlerted(lake string, kind alerts.Kind, t time.Time) error {
+	return s.db.NewRef("/alerts/" + lake + "/" + string(kind)).Set(s.ctx, t.Format(time.RFC3339))
+}
+
+var _ alerts.StateStore = (*firebaseAlertState)(nil)
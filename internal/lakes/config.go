@@ -0,0 +1,84 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/supcik/lake-level-updater-2018/alerts"
+)
+
+const defaultConfigPath = "sources.yaml"
+const defaultAlertsConfigPath = "alerts.yaml"
+
+// SourceConfig describes a single data source as read from sources.yaml.
+type SourceConfig struct {
+	Name   string `yaml:"name"`
+	URL    string `yaml:"url"`
+	Parser string `yaml:"parser"`
+
+	// Fields is the parser-specific field mapping: CSS selectors for
+	// "groupe-e", JSON paths for "json", column indices for "ics".
+	Fields map[string]string `yaml:"fields"`
+	// DateFormat is the Go reference layout used to parse dates, when
+	// the parser needs one.
+	DateFormat string `yaml:"date_format"`
+}
+
+// Config is the top level structure of sources.yaml.
+type Config struct {
+	Sources []SourceConfig `yaml:"sources"`
+}
+
+// loadConfig reads and parses the source configuration file. If path is
+// empty, defaultConfigPath is used.
+func loadConfig(path string) (*Config, error) {
+	if path == "" {
+		path = defaultConfigPath
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadAlertsConfig reads and parses the alerts configuration file. If
+// path is empty, defaultAlertsConfigPath is used. A missing file is not
+// an error: it simply means no alerts are configured.
+func loadAlertsConfig(path string) (*alerts.Config, error) {
+	if path == "" {
+		path = defaultAlertsConfigPath
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &alerts.Config{}, nil
+		}
+		return nil, err
+	}
+	var cfg alerts.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
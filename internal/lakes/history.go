@@ -0,0 +1,136 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"context"
+	"time"
+
+	"firebase.google.com/go/db"
+)
+
+// appendHistory writes l into
+// /history/<source>/<lake>/<yyyy>/<mm>/<dd>/<pushID>, in addition to the
+// /current/<source>/<lake> overwrite already performed by Run. This
+// keeps every past reading around for Compact and QueryHistory. name is
+// "<source>/<lake>", matching the path Run already builds for /current.
+func appendHistory(ctx context.Context, dbClient *db.Client, name string, l Lake) error {
+	_, err := dbClient.NewRef("/history/" + name + "/" + l.Date.Format("2006/01/02")).Push(ctx, &l)
+	return err
+}
+
+// dayReadings holds every reading pushed for one lake on one day, keyed
+// by Firebase push id.
+type dayReadings map[string]Lake
+
+// rawHistory mirrors the /history tree written by appendHistory:
+// source -> lake -> year -> month -> day -> dayReadings.
+type rawHistory map[string]map[string]map[string]map[string]map[string]dayReadings
+
+// Compact reads the raw history for every lake and writes daily
+// min/max/avg aggregates under /daily/<source>/<lake>, keeping the
+// source segment so that two sources producing a lake with the same
+// name (e.g. two cantonal providers both reporting a "Lac Noir") don't
+// collide, exactly as /current and /history already do. It is wired to
+// an App Engine cron job hitting "/compact"; cmd/laked does not call it.
+func Compact(ctx context.Context, dbClient *db.Client) error {
+	var history rawHistory
+	if err := dbClient.NewRef("/history").Get(ctx, &history); err != nil {
+		return err
+	}
+
+	for name, daily := range aggregateHistory(history) {
+		for date, agg := range daily {
+			ref := dbClient.NewRef("/daily/" + name + "/" + date)
+			if err := ref.Set(ctx, &agg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// aggregateHistory reduces a rawHistory tree into, for every
+// "<source>/<lake>", one aggregated Lake per day (keyed by
+// "2006-01-02"), ready to be written under /daily/<source>/<lake>. This
+// is pure so it can be unit tested without a Firebase client.
+func aggregateHistory(history rawHistory) map[string]map[string]Lake {
+	result := make(map[string]map[string]Lake)
+	for source, lakes := range history {
+		for lake, years := range lakes {
+			name := source + "/" + lake
+			for _, months := range years {
+				for _, days := range months {
+					for _, readings := range days {
+						agg, date := dailyAggregate(readings)
+						if result[name] == nil {
+							result[name] = make(map[string]Lake)
+						}
+						result[name][date.Format("2006-01-02")] = agg
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// dailyAggregate reduces a day's worth of readings for one lake into a
+// single Lake with Min, Max and Avg filled in from Today.
+func dailyAggregate(readings dayReadings) (Lake, time.Time) {
+	var agg Lake
+	var sum float64
+	first := true
+	for _, l := range readings {
+		if first {
+			agg.Name = l.Name
+			agg.MaxLevel = l.MaxLevel
+			agg.Date = l.Date
+			agg.Min = l.Today
+			agg.Max = l.Today
+			first = false
+		}
+		if l.Today < agg.Min {
+			agg.Min = l.Today
+		}
+		if l.Today > agg.Max {
+			agg.Max = l.Today
+		}
+		sum += l.Today
+	}
+	if len(readings) > 0 {
+		agg.Avg = sum / float64(len(readings))
+	}
+	return agg, agg.Date
+}
+
+// QueryHistory returns the daily aggregates, for the lake named
+// "<source>/<lake>" (matching the key Compact writes under /daily),
+// whose date falls within [from, to], inclusive.
+func QueryHistory(ctx context.Context, dbClient *db.Client, name string, from, to time.Time) ([]Lake, error) {
+	var daily map[string]Lake
+	if err := dbClient.NewRef("/daily/" + name).Get(ctx, &daily); err != nil {
+		return nil, err
+	}
+
+	result := make([]Lake, 0, len(daily))
+	for _, l := range daily {
+		if l.Date.Before(from) || l.Date.After(to) {
+			continue
+		}
+		result = append(result, l)
+	}
+	return result, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyAggregate(t *testing.T) {
+	date := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	readings := dayReadings{
+		"push1": {Name: "Lac de la Gruyère", MaxLevel: 800, Date: date, Today: 10},
+		"push2": {Name: "Lac de la Gruyère", MaxLevel: 800, Date: date, Today: 20},
+		"push3": {Name: "Lac de la Gruyère", MaxLevel: 800, Date: date, Today: 30},
+	}
+
+	agg, gotDate := dailyAggregate(readings)
+
+	if !gotDate.Equal(date) {
+		t.Errorf("date = %v, want %v", gotDate, date)
+	}
+	if agg.Min != 10 {
+		t.Errorf("Min = %v, want 10", agg.Min)
+	}
+	if agg.Max != 30 {
+		t.Errorf("Max = %v, want 30", agg.Max)
+	}
+	if agg.Avg != 20 {
+		t.Errorf("Avg = %v, want 20", agg.Avg)
+	}
+	if agg.Name != "Lac de la Gruyère" {
+		t.Errorf("Name = %q, want %q", agg.Name, "Lac de la Gruyère")
+	}
+}
+
+func TestDailyAggregateEmpty(t *testing.T) {
+	agg, date := dailyAggregate(dayReadings{})
+	if !date.IsZero() {
+		t.Errorf("date = %v, want zero value", date)
+	}
+	if agg.Avg != 0 {
+		t.Errorf("Avg = %v, want 0", agg.Avg)
+	}
+}
+
+// TestAggregateHistoryMatchesWritePath builds a rawHistory tree shaped
+// exactly like the one appendHistory writes (source -> lake -> yyyy ->
+// mm -> dd -> pushID -> Lake) and checks that aggregateHistory's output
+// is keyed the way Compact and QueryHistory expect: by "<source>/<lake>",
+// so that two sources reporting a lake with the same name don't collide.
+func TestAggregateHistoryMatchesWritePath(t *testing.T) {
+	date := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	history := rawHistory{
+		"groupe-e": {
+			"Lac de la Gruyère": {
+				"2018": {
+					"06": {
+						"01": {
+							"push1": {Name: "Lac de la Gruyère", Date: date, Today: 10},
+							"push2": {Name: "Lac de la Gruyère", Date: date, Today: 30},
+						},
+					},
+				},
+			},
+		},
+		"other-source": {
+			"Lac de la Gruyère": {
+				"2018": {
+					"06": {
+						"01": {
+							"push1": {Name: "Lac de la Gruyère", Date: date, Today: 100},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	daily := aggregateHistory(history)
+
+	byDate, ok := daily["groupe-e/Lac de la Gruyère"]
+	if !ok {
+		t.Fatalf("aggregateHistory()[%q] missing, got keys %v", "groupe-e/Lac de la Gruyère", daily)
+	}
+	agg, ok := byDate["2018-06-01"]
+	if !ok {
+		t.Fatalf("aggregateHistory()[...][%q] missing, got keys %v", "2018-06-01", byDate)
+	}
+	if agg.Min != 10 || agg.Max != 30 {
+		t.Errorf("agg = %+v, want Min=10 Max=30", agg)
+	}
+
+	otherAgg, ok := daily["other-source/Lac de la Gruyère"]["2018-06-01"]
+	if !ok {
+		t.Fatalf("aggregateHistory()[%q] missing", "other-source/Lac de la Gruyère")
+	}
+	if otherAgg.Min != 100 || otherAgg.Max != 100 {
+		t.Errorf("otherAgg = %+v, want Min=Max=100 (must not collide with groupe-e's entry)", otherAgg)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lakes holds the scraping and Firebase-write logic shared by
+// the App Engine handler (main.go) and the standalone laked daemon
+// (cmd/laked). It only depends on context.Context and a plain
+// *http.Client, so it runs the same way under either deployment mode.
+package lakes
+
+import (
+	"context"
+	"time"
+
+	"firebase.google.com/go"
+	"firebase.google.com/go/db"
+)
+
+// Lake is the structure for storing lake information.
+type Lake struct {
+	Name      string    `datastore:"name"`
+	MaxLevel  float64   `datastore:"max_level,noindex"`
+	Date      time.Time `datastore:"date,noindex"`
+	Today     float64   `datastore:"today,noindex"`
+	Yesterday float64   `datastore:"yesterday,noindex"`
+
+	// Min, Max and Avg are only populated on the /daily aggregates
+	// produced by Compact; they are left zero on /current and /history
+	// entries.
+	Min float64 `datastore:"min,noindex"`
+	Max float64 `datastore:"max,noindex"`
+	Avg float64 `datastore:"avg,noindex"`
+}
+
+// Lakes is the list of all fetched lakes.
+type Lakes map[string]Lake
+
+// Database opens the Firebase Realtime Database used to store both the
+// current readings and the history/daily aggregates.
+func Database(ctx context.Context) (*db.Client, error) {
+	fbConfig := &firebase.Config{
+		DatabaseURL: "https://niveau-lacs.firebaseio.com/",
+	}
+	app, err := firebase.NewApp(ctx, fbConfig)
+	if err != nil {
+		return nil, err
+	}
+	return app.Database(ctx)
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	lakeLevelGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lake_level_updater_lake_level_msm",
+		Help: "Current lake level, in metres above sea level.",
+	}, []string{"lake"})
+
+	scrapeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "lake_level_updater_scrape_duration_seconds",
+		Help: "Duration of a source scrape, in seconds.",
+	}, []string{"source"})
+
+	scrapeSuccess = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lake_level_updater_scrape_success_total",
+		Help: "Number of successful source scrapes.",
+	}, []string{"source"})
+
+	scrapeFailure = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lake_level_updater_scrape_failure_total",
+		Help: "Number of failed source scrapes.",
+	}, []string{"source"})
+
+	firebaseWriteLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "lake_level_updater_firebase_write_latency_seconds",
+		Help: "Latency of writes to the Firebase Realtime Database, in seconds.",
+	})
+
+	lastSuccessfulScrape = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lake_level_updater_last_successful_scrape_timestamp_seconds",
+		Help: "Unix timestamp of the last successful scrape of a source.",
+	}, []string{"source"})
+)
+
+// timeSince observes the elapsed time since start in the given
+// HistogramVec, labeled by label.
+func timeSince(h *prometheus.HistogramVec, label string, start time.Time) {
+	h.WithLabelValues(label).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// rendererEnvVar selects the rendering backend used to fetch pages that
+// need a JavaScript-rendered DOM. Set it to "chromedp" to enable the
+// headless-browser fallback; any other value (or unset) keeps the fast
+// plain urlfetch/http.Client path.
+const rendererEnvVar = "RENDERER"
+
+const (
+	defaultRenderTimeout  = 30 * time.Second
+	defaultRenderSelector = "table"
+)
+
+// useChromedp reports whether the headless-browser backend is enabled.
+func useChromedp() bool {
+	return os.Getenv(rendererEnvVar) == "chromedp"
+}
+
+// renderPage loads pageURL in a headless Chrome instance, waits until
+// waitFor is present in the DOM and returns the rendered HTML. If
+// waitFor is empty, defaultRenderSelector is used.
+func renderPage(pageURL, waitFor string, timeout time.Duration) (string, error) {
+	if waitFor == "" {
+		waitFor = defaultRenderSelector
+	}
+	if timeout == 0 {
+		timeout = defaultRenderTimeout
+	}
+
+	ctx, cancel := chromedp.NewContext(context.Background())
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var html string
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(pageURL),
+		chromedp.WaitReady(waitFor, chromedp.ByQuery),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if err != nil {
+		return "", err
+	}
+	return html, nil
+}
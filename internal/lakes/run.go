@@ -0,0 +1,128 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/rs/zerolog/log"
+
+	"github.com/supcik/lake-level-updater-2018/alerts"
+)
+
+// Run fetches every configured source, writes its current reading and
+// history into dbClient, and checks alert thresholds. It is the single
+// pass performed once per cron tick (App Engine) or once per tick of the
+// daemon's ticker (cmd/laked).
+//
+// A failing source (fetch, parse, or write error) is logged, and fetch
+// and parse errors are also counted via scrapeFailure; a failure does not
+// stop the remaining sources from being
+// processed: one flaky feed should not take down the others. Run
+// collects every source error and returns them all together once the
+// pass is done.
+func Run(ctx context.Context, client *http.Client, dbClient *db.Client) error {
+	cfg, err := loadConfig(defaultConfigPath)
+	if err != nil {
+		return err
+	}
+	sources, err := buildSources(cfg)
+	if err != nil {
+		return err
+	}
+
+	readings := make(map[string]alerts.Reading)
+	var errs []string
+
+	for _, src := range sources {
+		if err := runSource(ctx, client, dbClient, src, readings); err != nil {
+			log.Error().Err(err).Str("source", src.Name()).Msg("error processing source")
+			errs = append(errs, fmt.Sprintf("%s: %v", src.Name(), err))
+		}
+	}
+
+	if err := checkAlerts(ctx, dbClient, readings); err != nil {
+		errs = append(errs, fmt.Sprintf("alerts: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d error(s): %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// runSource fetches and parses one source, writes its lakes' current
+// reading and history into dbClient, and records its readings for the
+// alerts pass.
+func runSource(ctx context.Context, client *http.Client, dbClient *db.Client, src Source, readings map[string]alerts.Reading) error {
+	start := time.Now()
+	body, err := src.Fetch(client)
+	if err != nil {
+		scrapeFailure.WithLabelValues(src.Name()).Inc()
+		return err
+	}
+	parsed, err := src.Parse(body)
+	body.Close()
+	timeSince(scrapeDuration, src.Name(), start)
+	if err != nil {
+		scrapeFailure.WithLabelValues(src.Name()).Inc()
+		return err
+	}
+	scrapeSuccess.WithLabelValues(src.Name()).Inc()
+	lastSuccessfulScrape.WithLabelValues(src.Name()).SetToCurrentTime()
+
+	log.Info().Str("source", src.Name()).Int("lakes", len(parsed)).Msg("scraped source")
+	for name, l := range parsed {
+		writeStart := time.Now()
+		ref := dbClient.NewRef("/current/" + src.Name() + "/" + name)
+		err := ref.Set(ctx, &l)
+		firebaseWriteLatency.Observe(time.Since(writeStart).Seconds())
+		if err != nil {
+			return err
+		}
+		if err := appendHistory(ctx, dbClient, src.Name()+"/"+name, l); err != nil {
+			return err
+		}
+		lakeLevelGauge.WithLabelValues(name).Set(l.Today)
+		readings[name] = alerts.Reading{
+			Name:      l.Name,
+			MaxLevel:  l.MaxLevel,
+			Today:     l.Today,
+			Yesterday: l.Yesterday,
+		}
+	}
+	return nil
+}
+
+// checkAlerts loads the alerts configuration and fires any threshold
+// crossing found in readings, using dbClient to persist cool-down state
+// under /alerts/<lake>/<kind>.
+func checkAlerts(ctx context.Context, dbClient *db.Client, readings map[string]alerts.Reading) error {
+	cfg, err := loadAlertsConfig(defaultAlertsConfigPath)
+	if err != nil {
+		return err
+	}
+	dispatchers, err := alerts.BuildDispatchers(cfg.Dispatchers)
+	if err != nil {
+		return err
+	}
+	state := &firebaseAlertState{ctx: ctx, db: dbClient}
+	return alerts.Check(*cfg, readings, state, dispatchers, time.Now())
+}
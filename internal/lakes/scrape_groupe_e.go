@@ -0,0 +1,129 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// groupeESource scrapes the HTML lake level table published by Groupe E.
+// If the "table" selector turns out to need JavaScript to render, Fetch
+// can fall back to a headless-browser backend (see renderer.go).
+type groupeESource struct {
+	name       string
+	url        string
+	waitFor    string
+	renderWait time.Duration
+}
+
+func newGroupeESource(cfg SourceConfig) (Source, error) {
+	s := &groupeESource{
+		name:    cfg.Name,
+		url:     cfg.URL,
+		waitFor: cfg.Fields["wait_for"],
+	}
+	if v, ok := cfg.Fields["render_timeout"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: invalid render_timeout %q: %v", cfg.Name, v, err)
+		}
+		s.renderWait = d
+	}
+	return s, nil
+}
+
+func (s *groupeESource) Name() string {
+	return s.name
+}
+
+func (s *groupeESource) Fetch(client *http.Client) (io.ReadCloser, error) {
+	if useChromedp() {
+		html, err := renderPage(s.url, s.waitFor, s.renderWait)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(strings.NewReader(html)), nil
+	}
+	resp, err := client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// msm parses a string representing a lake's level ang returns a float
+// note: "msm" means "m√®tres sur mer" (in french) which means "metres above sea level"
+func msm(t string) float64 {
+	re := regexp.MustCompile(`(\d+\.\d+).*msm`)
+	n := re.FindStringSubmatch(t)
+	if n != nil {
+		nf, err := strconv.ParseFloat(n[1], 64)
+		if err == nil {
+			return nf
+		}
+	}
+	return 0
+}
+
+// Parse reads the web page from "Groupe E" and extracts relevant
+// information for lake level.
+func (s *groupeESource) Parse(r io.Reader) (Lakes, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	result := make(Lakes)
+	table := doc.Find("table").First()
+	header := table.Find("thead tr th")
+	date1, err := time.Parse("2.1.2006", strings.TrimSpace(header.Eq(2).Text()))
+	if err != nil {
+		return nil, err
+	}
+	date2, err := time.Parse("2.1.2006", strings.TrimSpace(header.Eq(3).Text()))
+	if err != nil {
+		return nil, err
+	}
+	body := table.Find("tbody tr")
+	body.Each(func(i int, selection *goquery.Selection) {
+		name := strings.TrimSpace(selection.Find("td").Eq(0).Text())
+		maxLevel := msm(strings.TrimSpace(selection.Find("td").Eq(1).Text()))
+		l1 := msm(strings.TrimSpace(selection.Find("td").Eq(2).Text()))
+		l2 := msm(strings.TrimSpace(selection.Find("td").Eq(3).Text()))
+		lake := Lake{
+			Name:     name,
+			MaxLevel: maxLevel,
+		}
+		if date1.After(date2) {
+			lake.Date = date1
+			lake.Today = l1
+			lake.Yesterday = l2
+		} else {
+			lake.Date = date2
+			lake.Today = l2
+			lake.Yesterday = l1
+		}
+		result[name] = lake
+	})
+	return result, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsSource scrapes a CSV feed (ICS/CSV open-data export). The field
+// mapping gives the column index, as a string, for each Lake field.
+type icsSource struct {
+	name       string
+	url        string
+	columns    map[string]int
+	dateFormat string
+}
+
+func newICSSource(cfg SourceConfig) (Source, error) {
+	columns := make(map[string]int)
+	for _, f := range []string{"name", "max_level", "today", "yesterday", "date"} {
+		raw, ok := cfg.Fields[f]
+		if !ok {
+			return nil, fmt.Errorf("ics source %q: missing column mapping for %q", cfg.Name, f)
+		}
+		col, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ics source %q: column for %q must be an index: %v", cfg.Name, f, err)
+		}
+		columns[f] = col
+	}
+	dateFormat := cfg.DateFormat
+	if dateFormat == "" {
+		dateFormat = "2006-01-02"
+	}
+	return &icsSource{
+		name:       cfg.Name,
+		url:        cfg.URL,
+		columns:    columns,
+		dateFormat: dateFormat,
+	}, nil
+}
+
+func (s *icsSource) Name() string {
+	return s.name
+}
+
+func (s *icsSource) Fetch(client *http.Client) (io.ReadCloser, error) {
+	resp, err := client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *icsSource) Parse(r io.Reader) (Lakes, error) {
+	reader := csv.NewReader(r)
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	result := make(Lakes)
+	for i, row := range rows {
+		if i == 0 {
+			continue // header
+		}
+		name := strings.TrimSpace(row[s.columns["name"]])
+		date, err := time.Parse(s.dateFormat, strings.TrimSpace(row[s.columns["date"]]))
+		if err != nil {
+			return nil, err
+		}
+		maxLevel, _ := strconv.ParseFloat(strings.TrimSpace(row[s.columns["max_level"]]), 64)
+		today, _ := strconv.ParseFloat(strings.TrimSpace(row[s.columns["today"]]), 64)
+		yesterday, _ := strconv.ParseFloat(strings.TrimSpace(row[s.columns["yesterday"]]), 64)
+		result[name] = Lake{
+			Name:      name,
+			MaxLevel:  maxLevel,
+			Today:     today,
+			Yesterday: yesterday,
+			Date:      date,
+		}
+	}
+	return result, nil
+}
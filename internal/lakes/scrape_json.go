@@ -0,0 +1,90 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// jsonSource scrapes an arbitrary JSON open-data endpoint. The field
+// mapping in the config gives the gjson path for each Lake field.
+type jsonSource struct {
+	name       string
+	url        string
+	fields     map[string]string
+	dateFormat string
+}
+
+func newJSONSource(cfg SourceConfig) (Source, error) {
+	for _, f := range []string{"name", "max_level", "today", "yesterday", "date"} {
+		if _, ok := cfg.Fields[f]; !ok {
+			return nil, fmt.Errorf("json source %q: missing field mapping for %q", cfg.Name, f)
+		}
+	}
+	dateFormat := cfg.DateFormat
+	if dateFormat == "" {
+		dateFormat = time.RFC3339
+	}
+	return &jsonSource{
+		name:       cfg.Name,
+		url:        cfg.URL,
+		fields:     cfg.Fields,
+		dateFormat: dateFormat,
+	}, nil
+}
+
+func (s *jsonSource) Name() string {
+	return s.name
+}
+
+func (s *jsonSource) Fetch(client *http.Client) (io.ReadCloser, error) {
+	resp, err := client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *jsonSource) Parse(r io.Reader) (Lakes, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(raw) {
+		return nil, fmt.Errorf("json source %q: invalid JSON payload", s.name)
+	}
+	name := gjson.GetBytes(raw, s.fields["name"]).String()
+	if name == "" {
+		return nil, fmt.Errorf("json source %q: %s did not resolve to a name", s.name, s.fields["name"])
+	}
+	date, err := time.Parse(s.dateFormat, gjson.GetBytes(raw, s.fields["date"]).String())
+	if err != nil {
+		return nil, err
+	}
+	lake := Lake{
+		Name:      name,
+		MaxLevel:  gjson.GetBytes(raw, s.fields["max_level"]).Float(),
+		Today:     gjson.GetBytes(raw, s.fields["today"]).Float(),
+		Yesterday: gjson.GetBytes(raw, s.fields["yesterday"]).Float(),
+		Date:      date,
+	}
+	return Lakes{name: lake}, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Jacques Supcik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lakes
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Source fetches raw data from a provider and turns it into Lakes.
+// Each parser type (HTML table, JSON endpoint, ICS/CSV feed, ...)
+// implements this interface so that Run can treat every provider
+// the same way.
+type Source interface {
+	// Name identifies the source, used as the Firebase path segment.
+	Name() string
+	// Fetch retrieves the raw document for this source.
+	Fetch(client *http.Client) (io.ReadCloser, error)
+	// Parse turns the raw document into Lakes.
+	Parse(r io.Reader) (Lakes, error)
+}
+
+// sourceFactory builds a Source from its config entry.
+type sourceFactory func(cfg SourceConfig) (Source, error)
+
+// sourceFactories maps a config "parser" value to the factory that
+// knows how to build that kind of Source.
+var sourceFactories = map[string]sourceFactory{
+	"groupe-e": newGroupeESource,
+	"json":     newJSONSource,
+	"ics":      newICSSource,
+}
+
+// buildSources turns every entry of a Config into a Source.
+func buildSources(cfg *Config) ([]Source, error) {
+	sources := make([]Source, 0, len(cfg.Sources))
+	for _, sc := range cfg.Sources {
+		factory, ok := sourceFactories[sc.Parser]
+		if !ok {
+			return nil, fmt.Errorf("unknown parser type %q for source %q", sc.Parser, sc.Name)
+		}
+		src, err := factory(sc)
+		if err != nil {
+			return nil, fmt.Errorf("building source %q: %v", sc.Name, err)
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
@@ -15,143 +15,111 @@
 // This program fetches the level of some lakes in the canton of Fribourg
 // and makes them available for simple web sites of for IoT.
 // It stores the lake levels in a Firebase Realtime Database
-
+//
+// main.go is a thin App Engine adapter: all the scraping and
+// Firebase-write logic lives in internal/lakes so it can be shared with
+// the standalone cmd/laked daemon.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
 
-	"firebase.google.com/go"
-	"github.com/PuerkitoBio/goquery"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/appengine"
-	"google.golang.org/appengine/log"
 	"google.golang.org/appengine/urlfetch"
-)
 
-const (
-	pageURL = "https://www.groupe-e.ch/fr/univers-groupe-e/niveau-lacs"
+	"github.com/supcik/lake-level-updater-2018/internal/lakes"
 )
 
-// Lake is the structure for storing lake information.
-type Lake struct {
-	Name      string    `datastore:"name"`
-	MaxLevel  float64   `datastore:"max_level,noindex"`
-	Date      time.Time `datastore:"date,noindex"`
-	Today     float64   `datastore:"today,noindex"`
-	Yesterday float64   `datastore:"yesterday,noindex"`
-}
-
-// Lakes is the list of all fetched lakes.
-type Lakes map[string]Lake
+func handle(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	dbClient, err := lakes.Database(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("error connecting to database")
+		http.Error(w, "Application Server Error", 500)
+		return
+	}
 
-// msm parses a string representing a lake's level ang returns a float
-// note: "msm" means "m√®tres sur mer" (in french) which means "metres above sea level"
-func msm(t string) float64 {
-	re := regexp.MustCompile(`(\d+\.\d+).*msm`)
-	n := re.FindStringSubmatch(t)
-	if n != nil {
-		nf, err := strconv.ParseFloat(n[1], 64)
-		if err == nil {
-			return nf
-		}
+	if err := lakes.Run(ctx, urlfetch.Client(ctx), dbClient); err != nil {
+		log.Error().Err(err).Msg("error running scrape pass")
+		http.Error(w, "Application Server Error", 500)
+		return
 	}
-	return 0
+	fmt.Fprintln(w, "Done") // nolint: gas
 }
 
-// scrape reads the web page from "Groupe E" and extracts relevant information
-// for lake level.
-func scrape(r io.Reader) (Lakes, error) {
-	doc, err := goquery.NewDocumentFromReader(r)
-	if err != nil {
-		return nil, err
-	}
-	result := make(Lakes)
-	table := doc.Find("table").First()
-	header := table.Find("thead tr th")
-	date1, err := time.Parse("2.1.2006", strings.TrimSpace(header.Eq(2).Text()))
+func compact(w http.ResponseWriter, r *http.Request) {
+	ctx := appengine.NewContext(r)
+	dbClient, err := lakes.Database(ctx)
 	if err != nil {
-		return nil, err
+		log.Error().Err(err).Msg("error connecting to database")
+		http.Error(w, "Application Server Error", 500)
+		return
 	}
-	date2, err := time.Parse("2.1.2006", strings.TrimSpace(header.Eq(3).Text()))
-	if err != nil {
-		return nil, err
+	if err := lakes.Compact(ctx, dbClient); err != nil {
+		log.Error().Err(err).Msg("error compacting history")
+		http.Error(w, "Application Server Error", 500)
+		return
 	}
-	body := table.Find("tbody tr")
-	body.Each(func(i int, selection *goquery.Selection) {
-		name := strings.TrimSpace(selection.Find("td").Eq(0).Text())
-		maxLevel := msm(strings.TrimSpace(selection.Find("td").Eq(1).Text()))
-		l1 := msm(strings.TrimSpace(selection.Find("td").Eq(2).Text()))
-		l2 := msm(strings.TrimSpace(selection.Find("td").Eq(3).Text()))
-		lake := Lake{
-			Name:     name,
-			MaxLevel: maxLevel,
-		}
-		if date1.After(date2) {
-			lake.Date = date1
-			lake.Today = l1
-			lake.Yesterday = l2
-		} else {
-			lake.Date = date2
-			lake.Today = l2
-			lake.Yesterday = l1
-		}
-		result[name] = lake
-	})
-	return result, nil
+	fmt.Fprintln(w, "Done") // nolint: gas
 }
 
-func handle(w http.ResponseWriter, r *http.Request) {
+// historyAPI serves /api/history?source=...&lake=...&from=...&to=... with
+// the daily aggregates for lake in the given date range (inclusive), as
+// JSON. source must match the name a source was registered under in
+// sources.yaml, since /daily (like /current and /history) keeps lakes
+// from different sources separate even when they share a name.
+func historyAPI(w http.ResponseWriter, r *http.Request) {
 	ctx := appengine.NewContext(r)
-	resp, err := urlfetch.Client(ctx).Get(pageURL)
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		http.Error(w, "missing source parameter", http.StatusBadRequest)
+		return
+	}
+	lake := r.URL.Query().Get("lake")
+	if lake == "" {
+		http.Error(w, "missing lake parameter", http.StatusBadRequest)
+		return
+	}
+	from, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
 	if err != nil {
-		log.Errorf(ctx, "Error fetching URL : %v", err)
-		http.Error(w, "Application Server Error", 500)
+		http.Error(w, "invalid or missing from parameter", http.StatusBadRequest)
 		return
 	}
-	lakes, err := scrape(resp.Body)
+	to, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
 	if err != nil {
-		log.Errorf(ctx, "Error scraping data : %v", err)
-		http.Error(w, "Application Server Error", 500)
+		http.Error(w, "invalid or missing to parameter", http.StatusBadRequest)
 		return
 	}
 
-	fbConfig := &firebase.Config{
-		DatabaseURL: "https://niveau-lacs.firebaseio.com/",
-	}
-	app, err := firebase.NewApp(ctx, fbConfig)
+	dbClient, err := lakes.Database(ctx)
 	if err != nil {
-		log.Errorf(ctx, "Error creating firebase app: %v", err)
+		log.Error().Err(err).Msg("error connecting to database")
 		http.Error(w, "Application Server Error", 500)
 		return
 	}
 
-	dbClient, err := app.Database(ctx)
+	result, err := lakes.QueryHistory(ctx, dbClient, source+"/"+lake, from, to)
 	if err != nil {
-		log.Errorf(ctx, "Error connecting to database : %v", err)
+		log.Error().Err(err).Msg("error reading daily aggregates")
 		http.Error(w, "Application Server Error", 500)
 		return
 	}
 
-	log.Infof(ctx, "Lakes: %v", lakes)
-	for name, l := range lakes {
-		ref := dbClient.NewRef("/current/" + name)
-		err = ref.Set(ctx, &l)
-		if err != nil {
-			log.Errorf(ctx, "Error writing datastore : %v", err)
-			http.Error(w, "Application Server Error", 500)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Error().Err(err).Msg("error encoding history response")
 	}
-	fmt.Fprintln(w, "Done") // nolint: gas
 }
 
 func main() {
 	http.HandleFunc("/", handle)
+	http.HandleFunc("/compact", compact)
+	http.HandleFunc("/api/history", historyAPI)
+	http.Handle("/metrics", promhttp.Handler())
 	appengine.Main()
 }